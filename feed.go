@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/internetisalie/internetisalie.github.io/indexstore"
+)
+
+const feedFile = "feed.xml"
+const sitemapFile = "sitemap.xml"
+const siteURL = "https://internetisalie.github.io"
+const siteTitle = "internetisalie"
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title     string   `xml:"title"`
+	ID        string   `xml:"id"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type repoTimes struct {
+	Created time.Time
+	Updated time.Time
+}
+
+// walkCommitTimes walks the repository's commit history once and buckets
+// the first and most recent commit time that touched each top-level
+// directory, keyed by directory name. Doing this in a single pass avoids
+// re-walking the full log once per repository entry.
+func walkCommitTimes() (map[string]repoTimes, error) {
+	repo, err := git.PlainOpen(".")
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commitIter, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil, err
+	}
+	defer commitIter.Close()
+
+	times := map[string]repoTimes{}
+	err = commitIter.ForEach(func(commit *object.Commit) error {
+		stats, statErr := commit.Stats()
+		if statErr != nil {
+			return statErr
+		}
+
+		when := commit.Author.When
+		touched := map[string]bool{}
+		for _, stat := range stats {
+			name := topLevelDir(stat.Name)
+			if name == "" || touched[name] {
+				continue
+			}
+			touched[name] = true
+
+			t := times[name]
+			if t.Created.IsZero() || when.Before(t.Created) {
+				t.Created = when
+			}
+			if t.Updated.IsZero() || when.After(t.Updated) {
+				t.Updated = when
+			}
+			times[name] = t
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return times, nil
+}
+
+// topLevelDir returns the first path segment of path, or "" if path has
+// no directory component.
+func topLevelDir(path string) string {
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		return path[:i]
+	}
+
+	return ""
+}
+
+// commitTimes returns the first and most recent commit times recorded for
+// name in times, falling back to the directory's mtime if the working
+// tree isn't a git repository or times has no entry for name.
+func commitTimes(times map[string]repoTimes, name string) (time.Time, time.Time, error) {
+	if t, ok := times[name]; ok {
+		return t.Created, t.Updated, nil
+	}
+
+	return statTimes(name)
+}
+
+// statTimes falls back to the filesystem mtime when git history isn't
+// available for name.
+func statTimes(name string) (time.Time, time.Time, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return info.ModTime(), info.ModTime(), nil
+}
+
+// feed regenerates feed.xml and sitemap.xml from every entry recorded in
+// repository.json
+func feed() error {
+	entries, err := indexstore.LoadEntries(repositoryFile)
+	if err != nil {
+		return err
+	}
+
+	times, err := walkCommitTimes()
+	if err != nil {
+		times = map[string]repoTimes{}
+	}
+
+	doc := atomFeed{
+		Xmlns: "http://www.w3.org/2005/Atom",
+		Title: siteTitle,
+		ID:    siteURL + "/",
+		Links: []atomLink{
+			{Rel: "self", Href: siteURL + "/" + feedFile},
+			{Href: siteURL + "/"},
+		},
+	}
+	sitemap := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+
+	var latest time.Time
+	for _, entry := range entries {
+		name := entry.Name
+		created, updated, err := commitTimes(times, name)
+		if err != nil {
+			return err
+		}
+		if updated.After(latest) {
+			latest = updated
+		}
+
+		doc.Entries = append(doc.Entries, atomEntry{
+			Title:     name,
+			ID:        siteURL + "/" + name + "/",
+			Link:      atomLink{Href: siteURL + "/" + name + "/"},
+			Published: created.Format(time.RFC3339),
+			Updated:   updated.Format(time.RFC3339),
+		})
+
+		sitemap.URLs = append(sitemap.URLs, sitemapURL{
+			Loc:     siteURL + "/" + name + "/",
+			LastMod: updated.Format("2006-01-02"),
+		})
+	}
+	doc.Updated = latest.Format(time.RFC3339)
+
+	if err := writeXML(feedFile, doc); err != nil {
+		return err
+	}
+
+	return writeXML(sitemapFile, sitemap)
+}
+
+func writeXML(path string, v any) error {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out = append([]byte(xml.Header), out...)
+	return os.WriteFile(path, out, 0644)
+}
@@ -0,0 +1,318 @@
+// Package indexstore maintains the repository index as schema-versioned
+// JSON and regenerates the paginated index.html, README.md, and tags.html
+// from it, replacing the previous approach of re-parsing and mutating
+// those files in place on every insert. It renders those pages from its
+// own embedded html/template and text/template strings rather than the
+// main package's Renderer registry (render.go), which only covers
+// per-repository template instantiation.
+package indexstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	textTemplate "text/template"
+)
+
+// DefaultPageSize is used when a Store is created with a page size of 0.
+const DefaultPageSize = 100
+
+// SchemaVersion is written to repository.json's "version" field, so
+// future changes to Entry/Meta can be migrated on load.
+const SchemaVersion = 2
+
+// Meta holds the per-repository frontmatter parsed from
+// .template/meta.toml or .template/meta.yaml.
+type Meta struct {
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Created     string            `json:"created,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+}
+
+// Entry is one repository recorded in repository.json.
+type Entry struct {
+	Name string `json:"name"`
+	Meta Meta   `json:"meta"`
+}
+
+type document struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Store loads repository.json as the source of truth for the list of
+// known repositories, and regenerates index.html (paginated), README.md,
+// and tags.html from it whenever Flush is called.
+type Store struct {
+	path       string
+	indexPath  string
+	readmePath string
+	tagsPath   string
+	pageSize   int
+	entries    []Entry
+}
+
+// New loads a Store from path, or starts an empty one if path doesn't
+// exist yet.
+func New(path, indexPath, readmePath, tagsPath string, pageSize int) (*Store, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	store := &Store{
+		path:       path,
+		indexPath:  indexPath,
+		readmePath: readmePath,
+		tagsPath:   tagsPath,
+		pageSize:   pageSize,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entries, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	store.entries = entries
+
+	return store, nil
+}
+
+// LoadEntries reads path and returns its entries, understanding both the
+// current schema and the pre-schema bare array of names. Callers that
+// only need the recorded repositories (e.g. feed generation) should use
+// this instead of re-parsing repository.json against their own schema.
+func LoadEntries(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return decode(data)
+}
+
+// decode reads the current schema, falling back to the pre-schema format
+// (a bare JSON array of names) written before repository.json carried
+// metadata.
+func decode(data []byte) ([]Entry, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err == nil && doc.Version > 0 {
+		return doc.Entries, nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, len(names))
+	for i, name := range names {
+		entries[i] = Entry{Name: name}
+	}
+
+	return entries, nil
+}
+
+// Add records name and its metadata in the store, keeping entries sorted
+// by name. An existing entry with the same name is replaced. Call Flush
+// to persist the change.
+func (s *Store) Add(name string, meta Meta) {
+	i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].Name >= name })
+	entry := Entry{Name: name, Meta: meta}
+
+	if i < len(s.entries) && s.entries[i].Name == name {
+		s.entries[i] = entry
+		return
+	}
+
+	s.entries = append(s.entries, Entry{})
+	copy(s.entries[i+1:], s.entries[i:])
+	s.entries[i] = entry
+}
+
+// Flush writes repository.json, the paginated index.html pages,
+// README.md, and tags.html.
+func (s *Store) Flush() error {
+	data, err := json.MarshalIndent(document{Version: SchemaVersion, Entries: s.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(s.path, data, 0644); err != nil {
+		return err
+	}
+
+	if err = s.writeIndexPages(); err != nil {
+		return err
+	}
+
+	if err = s.writeReadme(); err != nil {
+		return err
+	}
+
+	return s.writeTagsPage()
+}
+
+type indexPage struct {
+	Entries []Entry
+	Page    int
+	Total   int
+	Prev    string
+	Next    string
+}
+
+func (s *Store) pages() [][]Entry {
+	if len(s.entries) == 0 {
+		return [][]Entry{{}}
+	}
+
+	var pages [][]Entry
+	for i := 0; i < len(s.entries); i += s.pageSize {
+		end := i + s.pageSize
+		if end > len(s.entries) {
+			end = len(s.entries)
+		}
+		pages = append(pages, s.entries[i:end])
+	}
+
+	return pages
+}
+
+func (s *Store) writeIndexPages() error {
+	pages := s.pages()
+
+	tpl, err := template.New("index").Parse(indexTemplateSource)
+	if err != nil {
+		return err
+	}
+
+	for i, entries := range pages {
+		page := indexPage{Entries: entries, Page: i + 1, Total: len(pages)}
+		if i > 0 {
+			page.Prev = pageFileName(s.indexPath, i)
+		}
+		if i < len(pages)-1 {
+			page.Next = pageFileName(s.indexPath, i+2)
+		}
+
+		var buf bytes.Buffer
+		if err = tpl.Execute(&buf, page); err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(pageFileName(s.indexPath, i+1), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pageFileName returns basePath for page 1 and "index-N.html" (relative
+// to basePath's directory) for subsequent pages.
+func pageFileName(basePath string, page int) string {
+	if page == 1 {
+		return basePath
+	}
+
+	ext := filepath.Ext(basePath)
+	base := basePath[:len(basePath)-len(ext)]
+	return fmt.Sprintf("%s-%d%s", base, page, ext)
+}
+
+func (s *Store) writeReadme() error {
+	tpl, err := textTemplate.New("readme").Parse(readmeTemplateSource)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, s.entries); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.readmePath, buf.Bytes(), 0644)
+}
+
+type tagGroup struct {
+	Tag     string
+	Entries []Entry
+}
+
+// writeTagsPage groups every entry by its meta.Tags and writes a single
+// tags.html taxonomy page, sorted by tag name.
+func (s *Store) writeTagsPage() error {
+	byTag := map[string][]Entry{}
+	for _, entry := range s.entries {
+		for _, tag := range entry.Meta.Tags {
+			byTag[tag] = append(byTag[tag], entry)
+		}
+	}
+
+	tags := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	groups := make([]tagGroup, len(tags))
+	for i, tag := range tags {
+		groups[i] = tagGroup{Tag: tag, Entries: byTag[tag]}
+	}
+
+	tpl, err := template.New("tags").Parse(tagsTemplateSource)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, groups); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.tagsPath, buf.Bytes(), 0644)
+}
+
+const indexTemplateSource = `<!DOCTYPE html>
+<html>
+<body>
+<div>
+<ul>
+{{range .Entries}}    <li><a href="/{{.Name}}">{{if .Meta.Title}}{{.Meta.Title}}{{else}}{{.Name}}{{end}}</a>{{if .Meta.Description}} - {{.Meta.Description}}{{end}}{{range .Meta.Tags}} <span class="tag">{{.}}</span>{{end}}</li>
+{{end}}</ul>
+{{if .Prev}}<a href="/{{.Prev}}">&larr; prev</a>{{end}}
+{{if .Next}}<a href="/{{.Next}}">next &rarr;</a>{{end}}
+</div>
+</body>
+</html>
+`
+
+const readmeTemplateSource = `# Repositories
+
+{{range .}}- [{{if .Meta.Title}}{{.Meta.Title}}{{else}}{{.Name}}{{end}}](/{{.Name}}){{if .Meta.Description}} - {{.Meta.Description}}{{end}}
+{{end}}`
+
+const tagsTemplateSource = `<!DOCTYPE html>
+<html>
+<body>
+<div>
+{{range .}}<h2>{{.Tag}}</h2>
+<ul>
+{{range .Entries}}    <li><a href="/{{.Name}}">{{if .Meta.Title}}{{.Meta.Title}}{{else}}{{.Name}}{{end}}</a></li>
+{{end}}</ul>
+{{end}}</div>
+</body>
+</html>
+`
@@ -0,0 +1,87 @@
+package indexstore
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func namesOf(entries []Entry) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name
+	}
+	return names
+}
+
+func TestStoreAddKeepsSortedAndDedups(t *testing.T) {
+	s := &Store{}
+
+	s.Add("charlie", Meta{})
+	s.Add("alpha", Meta{})
+	s.Add("bravo", Meta{})
+
+	if got, want := namesOf(s.entries), []string{"alpha", "bravo", "charlie"}; !equalStrings(got, want) {
+		t.Fatalf("entries = %v, want %v", got, want)
+	}
+
+	s.Add("bravo", Meta{Title: "updated"})
+	if len(s.entries) != 3 {
+		t.Fatalf("Add of an existing name should replace, not duplicate: got %d entries", len(s.entries))
+	}
+	if got := s.entries[1].Meta.Title; got != "updated" {
+		t.Fatalf("Add of an existing name should update its Meta: got title %q", got)
+	}
+}
+
+func TestStorePages(t *testing.T) {
+	s := &Store{pageSize: 2}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		s.Add(name, Meta{})
+	}
+
+	pages := s.pages()
+	if len(pages) != 3 {
+		t.Fatalf("got %d pages, want 3", len(pages))
+	}
+	if got, want := namesOf(pages[0]), []string{"a", "b"}; !equalStrings(got, want) {
+		t.Errorf("page 1 = %v, want %v", got, want)
+	}
+	if got, want := namesOf(pages[1]), []string{"c", "d"}; !equalStrings(got, want) {
+		t.Errorf("page 2 = %v, want %v", got, want)
+	}
+	if got, want := namesOf(pages[2]), []string{"e"}; !equalStrings(got, want) {
+		t.Errorf("page 3 = %v, want %v", got, want)
+	}
+}
+
+func TestStorePagesEmpty(t *testing.T) {
+	s := &Store{pageSize: 10}
+
+	pages := s.pages()
+	if len(pages) != 1 || len(pages[0]) != 0 {
+		t.Fatalf("pages() on an empty store = %v, want a single empty page", pages)
+	}
+}
+
+func TestPageFileName(t *testing.T) {
+	base := filepath.Join("site", "index.html")
+
+	if got, want := pageFileName(base, 1), base; got != want {
+		t.Errorf("pageFileName(%q, 1) = %q, want %q", base, got, want)
+	}
+	if got, want := pageFileName(base, 2), filepath.Join("site", "index-2.html"); got != want {
+		t.Errorf("pageFileName(%q, 2) = %q, want %q", base, got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
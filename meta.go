@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/internetisalie/internetisalie.github.io/indexstore"
+)
+
+const metaTOMLFile = "meta.toml"
+const metaYAMLFile = "meta.yaml"
+
+// loadMeta reads .template/meta.toml or .template/meta.yaml, returning a
+// zero Meta when neither is present.
+func loadMeta() (indexstore.Meta, error) {
+	values, err := readMetaValues(filepath.Join(templateDir, metaTOMLFile), decodeTOML)
+	if err != nil {
+		return indexstore.Meta{}, err
+	}
+	if values == nil {
+		values, err = readMetaValues(filepath.Join(templateDir, metaYAMLFile), decodeYAML)
+		if err != nil {
+			return indexstore.Meta{}, err
+		}
+	}
+
+	return metaFromValues(values), nil
+}
+
+func readMetaValues(path string, decode func([]byte, map[string]any) error) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+	if err = decode(data, values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func decodeTOML(data []byte, values map[string]any) error {
+	_, err := toml.Decode(string(data), &values)
+	return err
+}
+
+func decodeYAML(data []byte, values map[string]any) error {
+	return yaml.Unmarshal(data, &values)
+}
+
+// metaFromValues lifts the well-known title/description/tags/created keys
+// into Meta's fields, and stashes everything else in Extra.
+func metaFromValues(values map[string]any) indexstore.Meta {
+	meta := indexstore.Meta{Extra: map[string]string{}}
+
+	for key, value := range values {
+		switch key {
+		case "title":
+			meta.Title, _ = value.(string)
+		case "description":
+			meta.Description, _ = value.(string)
+		case "created":
+			meta.Created = fmt.Sprint(value)
+		case "tags":
+			if list, ok := value.([]any); ok {
+				for _, tag := range list {
+					meta.Tags = append(meta.Tags, fmt.Sprint(tag))
+				}
+			}
+		default:
+			meta.Extra[key] = fmt.Sprint(value)
+		}
+	}
+
+	return meta
+}
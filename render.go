@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/internetisalie/internetisalie.github.io/indexstore"
+)
+
+// markdownSanitizePolicy strips any HTML that survives markdown rendering
+// down to a safe subset, so frontmatter fields substituted into the
+// markdown source (title, description, ...) can't smuggle a stored XSS
+// payload into the companion .html file.
+var markdownSanitizePolicy = bluemonday.UGCPolicy()
+
+// RenderCtx is passed to every Renderer so templates can reference the
+// repository being instantiated and its frontmatter metadata.
+type RenderCtx struct {
+	Name string
+	Dir  string
+	Path string
+	Meta indexstore.Meta
+}
+
+// Renderer turns the contents of a single file under .template into the
+// bytes written to the new repository directory. instance iterates the
+// registry and uses the first Renderer whose Match reports true. This
+// contract covers per-repository instantiation only; indexstore renders
+// index.html/README.md/tags.html from its own embedded templates rather
+// than through this registry.
+type Renderer interface {
+	Name() string
+	Match(path string) bool
+	Render(ctx RenderCtx, in []byte) ([]byte, error)
+}
+
+// renderers is the registry instance consults, in priority order. The
+// passthroughRenderer is last and matches everything, so it only runs
+// when no more specific renderer claims the file.
+var renderers = []Renderer{
+	markdownRenderer{},
+	htmlRenderer{},
+	passthroughRenderer{},
+}
+
+func rendererFor(path string) Renderer {
+	for _, r := range renderers {
+		if r.Match(path) {
+			return r
+		}
+	}
+
+	return passthroughRenderer{}
+}
+
+// outputName strips the .tmpl suffix renderers key off of, so
+// ".template/index.html.tmpl" instantiates as "index.html".
+func outputName(path string) string {
+	return strings.TrimSuffix(path, ".tmpl")
+}
+
+// htmlRenderer executes html/template files, e.g. ".template/index.html.tmpl".
+type htmlRenderer struct{}
+
+func (htmlRenderer) Name() string { return "html" }
+
+func (htmlRenderer) Match(path string) bool {
+	return strings.HasSuffix(path, ".tmpl") && !strings.HasSuffix(path, ".md.tmpl")
+}
+
+func (htmlRenderer) Render(ctx RenderCtx, in []byte) ([]byte, error) {
+	tpl, err := template.New(ctx.Path).Parse(string(in))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, ctx); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// markdownRenderer executes ".template/*.md.tmpl" as a text template, then
+// renders the result to a companion .html file alongside the instantiated
+// .md, using gomarkdown.
+type markdownRenderer struct{}
+
+func (markdownRenderer) Name() string { return "markdown" }
+
+func (markdownRenderer) Match(path string) bool {
+	return strings.HasSuffix(path, ".md.tmpl")
+}
+
+func (markdownRenderer) Render(ctx RenderCtx, in []byte) ([]byte, error) {
+	tpl, err := texttemplate.New(ctx.Path).Parse(string(in))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, ctx); err != nil {
+		return nil, err
+	}
+
+	md := buf.Bytes()
+
+	rendered := markdownSanitizePolicy.SanitizeBytes(markdown.ToHTML(md, nil, nil))
+
+	htmlPath := filepath.Join(ctx.Dir, strings.TrimSuffix(filepath.Base(ctx.Path), ".md.tmpl")+".html")
+	if err = os.WriteFile(htmlPath, rendered, 0644); err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
+// passthroughRenderer copies a template file verbatim, for static assets
+// that live alongside the HTML/Markdown templates.
+type passthroughRenderer struct{}
+
+func (passthroughRenderer) Name() string { return "passthrough" }
+
+func (passthroughRenderer) Match(string) bool { return true }
+
+func (passthroughRenderer) Render(_ RenderCtx, in []byte) ([]byte, error) {
+	return in, nil
+}
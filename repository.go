@@ -1,49 +1,24 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"github.com/gomarkdown/markdown"
-	"github.com/gomarkdown/markdown/ast"
-	"github.com/gomarkdown/markdown/md"
-	"html/template"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
-	"slices"
+	"strconv"
 
-	"golang.org/x/net/html"
-
-	"github.com/gomarkdown/markdown/parser"
+	"github.com/internetisalie/internetisalie.github.io/indexstore"
 )
 
 const templateDir = `.template`
 const repositoryFile = `repository.json`
 const indexFile = `index.html`
 const readmeFile = `README.md`
+const tagsFile = `tags.html`
 
-// render instantiates a single template into the target directory
-func render(name, match string) error {
-	templateFile := filepath.Join(templateDir, match)
-
-	var tpl *template.Template
-	tpl, err := template.New(match).ParseFiles(templateFile)
-	if err != nil {
-		return err
-	}
-
-	output := filepath.Join(name, match)
-	writer, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
-	defer writer.Close()
-
-	return tpl.Execute(writer, name)
-}
-
+// instance walks every file under .template, renders it through the
+// registry in render.go, and writes the result into the new repository
+// directory.
 func instance(name string) error {
 	if err := os.MkdirAll(name, 0755); err != nil {
 		return err
@@ -56,187 +31,59 @@ func instance(name string) error {
 		return err
 	}
 
-	for _, match := range matches {
-		if err = render(name, match); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// index updates the root index.html link list
-func index(name string) error {
-	documentData, err := os.ReadFile(indexFile)
-	if err != nil {
-		return err
-	}
-
-	document, err := html.Parse(bytes.NewBuffer(documentData))
+	meta, err := loadMeta()
 	if err != nil {
 		return err
 	}
 
-	// find the unordered list
-	elementPathParts := []string{"html", "body", "div", "ul"}
-	element := document
-	for len(elementPathParts) > 0 {
-		child := element.FirstChild
-		for child != nil {
-			if child.Type == html.ElementNode && child.Data == elementPathParts[0] {
-				element = child
-				elementPathParts = elementPathParts[1:]
-				break
-			}
-			child = child.NextSibling
+	for _, match := range matches {
+		if match == metaTOMLFile || match == metaYAMLFile {
+			continue
 		}
-	}
-
-	// create our item
-	newChildren := []*html.Node{
-		{
-			Type: html.ElementNode,
-			Data: "li",
-			FirstChild: &html.Node{
-				Type: html.ElementNode,
-				Data: "a",
-				FirstChild: &html.Node{
-					Type: html.TextNode,
-					Data: name,
-				},
-				Attr: []html.Attribute{{
-					Key: "href",
-					Val: "/" + name,
-				}},
-			},
-		},
-		{
-			Type: html.TextNode,
-			Data: "\n            ",
-		},
-	}
 
-	// add our item
-	child := element.FirstChild
-	for child != nil {
-		if child.Type == html.ElementNode && child.FirstChild.FirstChild.Data >= name {
-			break
-		}
-		child = child.NextSibling
-	}
-	if child != nil {
-		if child.FirstChild.FirstChild.Data == name {
-			// don't duplicate
-			return nil
-		}
-		for _, newChild := range newChildren {
-			element.InsertBefore(newChild, child)
-		}
-	} else {
-		for _, newChild := range newChildren {
-			element.AppendChild(newChild)
+		in, err := os.ReadFile(filepath.Join(templateDir, match))
+		if err != nil {
+			return err
 		}
-	}
 
-	documentBuffer := new(bytes.Buffer)
-	if err = html.Render(documentBuffer, document); err != nil {
-		return err
-	}
-
-	return os.WriteFile(indexFile, documentBuffer.Bytes(), 0644)
-}
-
-// indexMarkdown updates the root index.md link list
-func readme(name string) error {
-	documentData, err := os.ReadFile(readmeFile)
-	if err != nil {
-		return err
-	}
-
-	// create Markdown parser
-	p := parser.NewWithExtensions(parser.CommonExtensions)
-
-	// create new list item
-	newListItemText := fmt.Sprintf("- [%s](/%s)\n", name, name)
-	newListItemList := p.Parse([]byte(newListItemText))
-	newListItem := newListItemList.
-		AsContainer().Children[0].
-		AsContainer().Children[0]
-	newListItem.(*ast.ListItem).ListFlags = 0
-
-	// parse document
-	p = parser.NewWithExtensions(parser.CommonExtensions)
-	document := p.Parse(documentData)
-	list := document.AsContainer().Children[1].AsContainer()
-	entries := list.Children
-	insertAt := len(entries)
-	for i, entry := range entries {
-		listItem := entry.AsContainer().Children[0]
-		link := listItem.AsContainer().Children[1]
-		linkText := link.AsContainer().Children[0]
-
-		content := string(linkText.AsLeaf().Literal)
-		if content == name {
-			return nil
-		} else if content > name {
-			insertAt = i
-			break
+		ctx := RenderCtx{Name: name, Dir: name, Path: match, Meta: meta}
+		out, err := rendererFor(match).Render(ctx, in)
+		if err != nil {
+			return err
 		}
-	}
 
-	if insertAt < len(entries) {
-		beforeEntries, afterEntries := entries[:insertAt], entries[insertAt:]
-		entries = slices.Clone(beforeEntries)
-		entries = append(entries, newListItem)
-		entries = append(entries, afterEntries...)
-		if insertAt == 0 {
-			newListItem.(*ast.ListItem).ListFlags = ast.ListItemBeginningOfList
-			// clear beginning of list on previously-first item
-			entries[1].(*ast.ListItem).ListFlags = 0
-		}
-	} else {
-		newListItem.(*ast.ListItem).ListFlags = ast.ListItemEndOfList
-		entries = append(entries, newListItem)
-		if len(entries) > 1 {
-			// clear end of list on previously-last item
-			entries[len(entries)-2].(*ast.ListItem).ListFlags = 0
+		output := filepath.Join(name, outputName(match))
+		if err = os.WriteFile(output, out, 0644); err != nil {
+			return err
 		}
 	}
 
-	list.Children = entries
-
-	renderer := md.NewRenderer()
-	documentData = markdown.Render(document, renderer)
-
-	return os.WriteFile(readmeFile, documentData, 0644)
+	return nil
 }
 
-func record(name string) error {
-	data, err := os.ReadFile(repositoryFile)
-	if err != nil {
-		return err
-	}
+const indexPageSizeEnv = "REPOSITORY_INDEX_PAGE_SIZE"
 
-	var repositories []string
-	if err = json.Unmarshal(data, &repositories); err != nil {
-		return err
-	}
+// openStore loads the IndexStore backing repository.json, index.html,
+// README.md, and tags.html.
+func openStore() (*indexstore.Store, error) {
+	return indexstore.New(repositoryFile, indexFile, readmeFile, tagsFile, indexPageSize())
+}
 
-	for _, repository := range repositories {
-		if repository == name {
-			return nil
-		}
+// indexPageSize lets REPOSITORY_INDEX_PAGE_SIZE override
+// indexstore.DefaultPageSize, so large repository lists can be tuned
+// without a code change.
+func indexPageSize() int {
+	raw := os.Getenv(indexPageSizeEnv)
+	if raw == "" {
+		return indexstore.DefaultPageSize
 	}
 
-	repositories = append(repositories, name)
-	slices.Sort(repositories)
-
-	data, err = json.MarshalIndent(repositories, "", "  ")
-	if err != nil {
-		return err
+	size, err := strconv.Atoi(raw)
+	if err != nil || size <= 0 {
+		return indexstore.DefaultPageSize
 	}
 
-	return os.WriteFile(repositoryFile, data, 0644)
+	return size
 }
 
 func main() {
@@ -245,21 +92,35 @@ func main() {
 		logger.Fatal("missing repository name argument")
 	}
 
+	if os.Args[1] == "serve" {
+		if err := serve(defaultAddr, serveToken(), logger); err != nil {
+			logger.Fatal(err.Error())
+		}
+		return
+	}
+
 	name := os.Args[1]
 
 	if err := instance(name); err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	if err := index(name); err != nil {
+	meta, err := loadMeta()
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	store, err := openStore()
+	if err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	if err := readme(name); err != nil {
+	store.Add(name, meta)
+	if err = store.Flush(); err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	if err := record(name); err != nil {
+	if err := feed(); err != nil {
 		logger.Fatal(err.Error())
 	}
 }
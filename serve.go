@@ -0,0 +1,289 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultAddr = ":8080"
+const authHeader = "Authorization"
+const requestIDHeader = "X-Request-Id"
+
+// nameRE restricts repository names accepted over HTTP to a single path
+// segment, rejecting traversal sequences and absolute paths before the
+// name ever reaches instance/store.Add.
+var nameRE = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// reservedNames are top-level paths instance/store.Add must never be
+// pointed at, since they're the site's own tooling rather than a
+// repository entry.
+var reservedNames = map[string]bool{
+	templateDir:    true,
+	repositoryFile: true,
+	indexFile:      true,
+	readmeFile:     true,
+	tagsFile:       true,
+	feedFile:       true,
+	sitemapFile:    true,
+	".git":         true,
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return errors.New("missing name")
+	}
+	if !nameRE.MatchString(name) {
+		return errors.New("invalid name")
+	}
+	if strings.HasPrefix(name, ".") {
+		return errors.New("invalid name")
+	}
+	if reservedNames[name] {
+		return errors.New("name is reserved")
+	}
+
+	if _, err := os.Stat(name); err == nil {
+		return errors.New("name already exists")
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// createEntryRequest is the shape accepted from all three content types:
+// x-www-form-urlencoded, multipart/form-data, and application/json. The
+// metadata fields are optional and, when set, override whatever is in
+// .template/meta.toml|yaml for this entry, the same way Description
+// already does.
+type createEntryRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Title       string   `json:"title"`
+	Tags        []string `json:"tags"`
+	Created     string   `json:"created"`
+}
+
+// server holds the dependencies the HTTP handlers need: the auth token,
+// and the logger that failed entry creations are reported to before the
+// handler answers with a 500.
+type server struct {
+	token  string
+	logger *log.Logger
+}
+
+// serve runs an authenticated HTTP endpoint that drives the same
+// instance/index/readme/record/feed pipeline as `repository <name>`, then
+// commits and pushes the result.
+func serve(addr, token string, logger *log.Logger) error {
+	if token == "" {
+		return errors.New("serve: missing auth token")
+	}
+
+	s := &server{token: token, logger: logger}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/entries", s.handleCreateEntry)
+
+	logger.Printf("listening on %s", addr)
+	return http.ListenAndServe(addr, withRequestID(withAccessLog(logger, mux)))
+}
+
+func (s *server) handleCreateEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req, err := parseCreateEntry(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateName(req.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.createEntry(req); err != nil {
+		s.logger.Printf("create entry %q: %s", req.Name, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *server) authorized(r *http.Request) bool {
+	got := r.Header.Get(authHeader)
+	want := "Bearer " + s.token
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+func (s *server) createEntry(req createEntryRequest) error {
+	if err := instance(req.Name); err != nil {
+		return err
+	}
+
+	meta, err := loadMeta()
+	if err != nil {
+		return err
+	}
+	if req.Description != "" {
+		meta.Description = req.Description
+	}
+	if req.Title != "" {
+		meta.Title = req.Title
+	}
+	if len(req.Tags) > 0 {
+		meta.Tags = req.Tags
+	}
+	if req.Created != "" {
+		meta.Created = req.Created
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	store.Add(req.Name, meta)
+	if err = store.Flush(); err != nil {
+		return err
+	}
+
+	if err := feed(); err != nil {
+		return err
+	}
+
+	return commitAndPush(req.Name)
+}
+
+func commitAndPush(name string) error {
+	if out, err := exec.Command("git", "add", "-A").CombinedOutput(); err != nil {
+		return fmt.Errorf("git add: %w: %s", err, out)
+	}
+
+	status, err := exec.Command("git", "status", "--porcelain").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git status: %w: %s", err, status)
+	}
+
+	// Nothing staged is not an error: skip the commit and still push.
+	if len(strings.TrimSpace(string(status))) > 0 {
+		commit := exec.Command("git", "commit", "-m", fmt.Sprintf("add %s", name))
+		if out, err := commit.CombinedOutput(); err != nil {
+			return fmt.Errorf("git commit: %w: %s", err, out)
+		}
+	}
+
+	if out, err := exec.Command("git", "push").CombinedOutput(); err != nil {
+		return fmt.Errorf("git push: %w: %s", err, out)
+	}
+
+	return nil
+}
+
+// parseCreateEntry accepts the same three content types Micropub's
+// CreateEntry handles: form-urlencoded, multipart, and JSON.
+func parseCreateEntry(r *http.Request) (createEntryRequest, error) {
+	var req createEntryRequest
+
+	contentType := r.Header.Get("Content-Type")
+	switch {
+	case jsonContentType(contentType):
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, fmt.Errorf("decoding json body: %w", err)
+		}
+	default:
+		if err := r.ParseMultipartForm(10 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return req, fmt.Errorf("parsing form: %w", err)
+		}
+		req.Name = r.FormValue("name")
+		req.Description = r.FormValue("description")
+		req.Title = r.FormValue("title")
+		req.Created = r.FormValue("created")
+		req.Tags = splitTags(r.FormValue("tags"))
+	}
+
+	return req, nil
+}
+
+func jsonContentType(contentType string) bool {
+	return len(contentType) >= len("application/json") && contentType[:len("application/json")] == "application/json"
+}
+
+// splitTags parses a comma-separated "tags" form value, since form bodies
+// can't carry a JSON array the way createEntryRequest.Tags does.
+func splitTags(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// withRequestID stamps every request with an id, reusing one supplied by
+// the caller if present.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withAccessLog logs method, path, status, request id, and duration for
+// every request.
+func withAccessLog(logger *log.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		logger.Printf("request_id=%s method=%s path=%s status=%d duration=%s",
+			rec.Header().Get(requestIDHeader), r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func serveToken() string {
+	return os.Getenv("REPOSITORY_SERVE_TOKEN")
+}
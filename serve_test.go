@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "existing"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	cases := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"", true},
+		{"my-post", false},
+		{"../escaped-evil", true},
+		{"/absolute", true},
+		{"nested/path", true},
+		{".", true},
+		{"..", true},
+		{".git", true},
+		{".template", true},
+		{".hidden", true},
+		{repositoryFile, true},
+		{indexFile, true},
+		{"existing", true},
+	}
+
+	for _, c := range cases {
+		err := validateName(c.name)
+		if (err != nil) != c.wantErr {
+			t.Errorf("validateName(%q) error = %v, wantErr %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"go", []string{"go"}},
+		{"go, testing,  cli ", []string{"go", "testing", "cli"}},
+		{"go,,testing", []string{"go", "testing"}},
+	}
+
+	for _, c := range cases {
+		got := splitTags(c.in)
+		if len(got) != len(c.want) {
+			t.Errorf("splitTags(%q) = %v, want %v", c.in, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("splitTags(%q) = %v, want %v", c.in, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseCreateEntryJSON(t *testing.T) {
+	body := strings.NewReader(`{"name":"foo","description":"a thing","tags":["a","b"]}`)
+	req := httptest.NewRequest(http.MethodPost, "/entries", body)
+	req.Header.Set("Content-Type", "application/json")
+
+	got, err := parseCreateEntry(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "foo" || got.Description != "a thing" || len(got.Tags) != 2 {
+		t.Errorf("parseCreateEntry(json) = %+v", got)
+	}
+}
+
+func TestParseCreateEntryForm(t *testing.T) {
+	form := url.Values{"name": {"foo"}, "description": {"a thing"}, "tags": {"a, b"}}
+	req := httptest.NewRequest(http.MethodPost, "/entries", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	got, err := parseCreateEntry(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "foo" || got.Description != "a thing" || len(got.Tags) != 2 {
+		t.Errorf("parseCreateEntry(form) = %+v", got)
+	}
+}